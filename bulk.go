@@ -0,0 +1,215 @@
+package dbc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// BulkOpts configures BulkInsert.
+type BulkOpts struct {
+	// DriverName selects a driver-specific fast path. "postgres" uses a
+	// single `COPY ... FROM STDIN`; any other value (or the zero value)
+	// uses chunked multi-row `INSERT ... VALUES` statements.
+	DriverName string
+	// BatchSize is the number of rows sent per multi-row INSERT
+	// statement. Ignored for the postgres COPY path. Defaults to 1000.
+	BatchSize int
+	// MaxParams caps the number of placeholders per statement, so a chunk
+	// never exceeds the driver's limit, e.g. 65535 for MySQL or 32767 for
+	// Postgres. Ignored for the postgres COPY path. Defaults to 65535.
+	MaxParams int
+}
+
+const (
+	defaultBulkBatchSize = 1000
+	defaultBulkMaxParams = 65535
+)
+
+func (o BulkOpts) withDefaults() BulkOpts {
+	if o.BatchSize <= 0 {
+		o.BatchSize = defaultBulkBatchSize
+	}
+	if o.MaxParams <= 0 {
+		o.MaxParams = defaultBulkMaxParams
+	}
+	return o
+}
+
+// bulkInsert inserts rows into table's columns using the fastest path
+// available for opts.DriverName, running in a transaction: a *DbHandle
+// opens one for the duration of the call, a *TxHandle is used as-is. It
+// returns the total number of rows affected. It backs the `Handle.BulkInsert`
+// method.
+func bulkInsert(ctx context.Context, h Handle, table string, columns []string, rows []DbRowV, opts BulkOpts) (int64, error) {
+	opts = opts.withDefaults()
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("dbc: BulkInsert requires at least one column")
+	}
+
+	if opts.DriverName == "postgres" {
+		return bulkInsertCopy(ctx, h, table, columns, rows)
+	}
+	return bulkInsertValues(ctx, h, table, columns, rows, opts)
+}
+
+// withTx runs fn against h, opening a transaction first if h is a
+// *DbHandle and reusing the existing transaction if h is already a
+// *TxHandle.
+func withTx(ctx context.Context, h Handle, fn func(Handle) error) error {
+	switch v := h.(type) {
+	case *DbHandle:
+		return v.RunInTx(ctx, nil, nil, func(tx *TxHandle) error { return fn(tx) })
+	default:
+		return fn(h)
+	}
+}
+
+func bulkInsertValues(ctx context.Context, h Handle, table string, columns []string, rows []DbRowV, opts BulkOpts) (int64, error) {
+	rowsPerChunk := opts.BatchSize
+	if perRow := opts.MaxParams / len(columns); perRow < rowsPerChunk {
+		rowsPerChunk = perRow
+	}
+	if rowsPerChunk < 1 {
+		return 0, fmt.Errorf("dbc: %d columns exceed MaxParams (%d)", len(columns), opts.MaxParams)
+	}
+
+	var total int64
+	err := withTx(ctx, h, func(h Handle) error {
+		for start := 0; start < len(rows); start += rowsPerChunk {
+			end := start + rowsPerChunk
+			if end > len(rows) {
+				end = len(rows)
+			}
+
+			query, args := bulkInsertValuesQuery(table, columns, rows[start:end])
+			res, err := h.ExecContext(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			total += n
+		}
+		return nil
+	})
+	return total, err
+}
+
+// bulkInsertValuesQuery builds a single multi-row
+// `INSERT INTO table (c1, c2) VALUES (?, ?), (?, ?), ...` statement for
+// rows, along with the flattened, positionally-matching argument list.
+func bulkInsertValuesQuery(table string, columns []string, rows []DbRowV) (string, []interface{}) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, r := range rows {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(placeholders)
+		args = append(args, r.V...)
+	}
+
+	return b.String(), args
+}
+
+// insertRe matches a single-row `INSERT INTO table (c1, c2, ...) VALUES
+// (...)` statement, capturing the table name and column list.
+var insertRe = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+(\S+)\s*\(([^)]*)\)\s*VALUES\s*\(`)
+
+// parseInsertColumns recovers the target table and column list from query
+// if it's a single-row INSERT statement of the form
+// `INSERT INTO table (c1, c2, ...) VALUES (...)`. It reports false for
+// anything else, e.g. an upsert with an `ON CONFLICT`/`ON DUPLICATE KEY`
+// clause, or multiple VALUES tuples already.
+func parseInsertColumns(query string) (table string, columns []string, ok bool) {
+	m := insertRe.FindStringSubmatchIndex(query)
+	if m == nil {
+		return "", nil, false
+	}
+
+	// Walk the VALUES tuple to its closing paren; anything after it other
+	// than an optional `;` (another tuple, an upsert clause) rules it out.
+	depth, i := 1, m[1]
+	for ; i < len(query) && depth > 0; i++ {
+		switch query[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	if depth != 0 || strings.TrimRight(strings.TrimSpace(query[i:]), "; \t\r\n") != "" {
+		return "", nil, false
+	}
+
+	for _, c := range strings.Split(query[m[4]:m[5]], ",") {
+		columns = append(columns, strings.TrimSpace(c))
+	}
+	return query[m[2]:m[3]], columns, true
+}
+
+// bulkInsertCopy bulk-loads rows via Postgres's `COPY ... FROM STDIN`,
+// which must run inside a transaction.
+func bulkInsertCopy(ctx context.Context, h Handle, table string, columns []string, rows []DbRowV) (int64, error) {
+	var total int64
+	err := withTx(ctx, h, func(h Handle) error {
+		tx, ok := h.(*TxHandle)
+		if !ok {
+			return fmt.Errorf("dbc: postgres COPY bulk insert requires a *TxHandle")
+		}
+
+		stmt, err := tx.T.PrepareContext(ctx, pq.CopyIn(table, columns...))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, r := range rows {
+			if _, err := stmt.ExecContext(ctx, r.V...); err != nil {
+				return err
+			}
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			return err
+		}
+
+		total = int64(len(rows))
+		return nil
+	})
+	return total, err
+}
+
+// mysqlLoadSeq generates unique reader-handler names for BulkLoadReader.
+var mysqlLoadSeq uint64
+
+// BulkLoadReader bulk-loads table from r using MySQL's
+// `LOAD DATA LOCAL INFILE`, for loads too large to build as a single
+// BulkInsert chunk. r must yield data in the server's expected LOAD DATA
+// format (tab-separated, newline-delimited fields by default).
+func BulkLoadReader(ctx context.Context, h Handle, table string, r io.Reader) (int64, error) {
+	name := fmt.Sprintf("dbc_bulk_%d", atomic.AddUint64(&mysqlLoadSeq, 1))
+	mysql.RegisterReaderHandler(name, func() io.Reader { return r })
+	defer mysql.DeregisterReaderHandler(name)
+
+	res, err := h.ExecContext(ctx, fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s", name, table))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
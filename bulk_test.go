@@ -0,0 +1,96 @@
+package dbc
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBulkInsertRejectsEmptyColumns(t *testing.T) {
+	_, err := bulkInsert(context.Background(), nil, "t", nil, []DbRowV{{V: []interface{}{1}}}, BulkOpts{})
+	if err == nil {
+		t.Fatal("expected an error for zero columns, got nil")
+	}
+}
+
+func TestBulkInsertValuesQuery(t *testing.T) {
+	rows := []DbRowV{
+		{V: []interface{}{1, "a"}},
+		{V: []interface{}{2, "b"}},
+	}
+
+	query, args := bulkInsertValuesQuery("accounts", []string{"id", "name"}, rows)
+
+	wantQuery := "INSERT INTO accounts (id, name) VALUES (?,?),(?,?)"
+	if query != wantQuery {
+		t.Errorf("query = %q, want %q", query, wantQuery)
+	}
+	if len(args) != 4 {
+		t.Fatalf("args = %v, want 4 values", args)
+	}
+	wantArgs := []interface{}{1, "a", 2, "b"}
+	for i, a := range args {
+		if a != wantArgs[i] {
+			t.Errorf("args[%d] = %v, want %v", i, a, wantArgs[i])
+		}
+	}
+}
+
+func TestBulkOptsWithDefaults(t *testing.T) {
+	o := BulkOpts{}.withDefaults()
+	if o.BatchSize != defaultBulkBatchSize {
+		t.Errorf("BatchSize = %d, want %d", o.BatchSize, defaultBulkBatchSize)
+	}
+	if o.MaxParams != defaultBulkMaxParams {
+		t.Errorf("MaxParams = %d, want %d", o.MaxParams, defaultBulkMaxParams)
+	}
+
+	o = BulkOpts{BatchSize: 10, MaxParams: 20}.withDefaults()
+	if o.BatchSize != 10 || o.MaxParams != 20 {
+		t.Errorf("withDefaults overrode explicit values: %+v", o)
+	}
+}
+
+func TestParseInsertColumns(t *testing.T) {
+	cases := []struct {
+		query      string
+		wantTable  string
+		wantCols   []string
+		wantParsed bool
+	}{
+		{
+			query:      "INSERT INTO accounts (id, name, email) VALUES (?, ?, ?)",
+			wantTable:  "accounts",
+			wantCols:   []string{"id", "name", "email"},
+			wantParsed: true,
+		},
+		{
+			query:      "insert into accounts(id,name) values ($1, $2)",
+			wantTable:  "accounts",
+			wantCols:   []string{"id", "name"},
+			wantParsed: true,
+		},
+		{
+			query:      "UPDATE accounts SET name = ? WHERE id = ?",
+			wantParsed: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			table, cols, ok := parseInsertColumns(tc.query)
+			if ok != tc.wantParsed {
+				t.Fatalf("parsed = %v, want %v", ok, tc.wantParsed)
+			}
+			if !ok {
+				return
+			}
+			if table != tc.wantTable {
+				t.Errorf("table = %q, want %q", table, tc.wantTable)
+			}
+			if strings.Join(cols, ",") != strings.Join(tc.wantCols, ",") {
+				t.Errorf("cols = %v, want %v", cols, tc.wantCols)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package dbc
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -23,9 +24,37 @@ var (
 // A handle can be a database connection or a transaction.
 type Handle interface {
 	Exec(string, ...interface{}) (sql.Result, error)
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
 	Prepare(string) (Statement, error)
-	// Query(string, ...interface{}) Rows
+	PrepareContext(context.Context, string) (Statement, error)
+	Query(string, ...interface{}) (Rows, error)
+	QueryContext(context.Context, string, ...interface{}) (Rows, error)
 	QueryRow(string, ...interface{}) Row
+	QueryRowContext(context.Context, string, ...interface{}) Row
+	// Rebind converts a query written with `?` placeholders into the
+	// handle's bind style, e.g. `$1`, `$2`, ... for Postgres.
+	Rebind(query string) string
+	// NamedExec rewrites query's `:name` placeholders against arg (a
+	// map[string]interface{} or a struct with `db:"..."` tags) and runs it
+	// through Exec.
+	NamedExec(query string, arg interface{}) (sql.Result, error)
+	// NamedQueryRow rewrites query's `:name` placeholders against arg (a
+	// map[string]interface{} or a struct with `db:"..."` tags) and runs it
+	// through QueryRow.
+	NamedQueryRow(query string, arg interface{}) Row
+	// Get runs q and scans the single resulting row into dest, a pointer
+	// to a struct. It returns `sql.ErrNoRows` unchanged when q matches no
+	// row.
+	Get(dest interface{}, q Query) error
+	// Select runs q and scans every resulting row into dest, which must
+	// be a pointer to a slice of structs or a slice of struct pointers.
+	Select(dest interface{}, q Query) error
+	// BulkInsert inserts rows into table's columns using the fastest
+	// path available for opts.DriverName, running in a transaction: on
+	// a *DbHandle it opens one for the duration of the call, on a
+	// *TxHandle it reuses the existing one. It returns the total number
+	// of rows affected.
+	BulkInsert(ctx context.Context, table string, columns []string, rows []DbRowV, opts BulkOpts) (int64, error)
 }
 
 // Row foo
@@ -46,8 +75,11 @@ type Rows interface {
 type Statement interface {
 	Close() error
 	Exec(...interface{}) (sql.Result, error)
-	// Query(...interface{}) Rows
+	ExecContext(context.Context, ...interface{}) (sql.Result, error)
+	Query(...interface{}) (Rows, error)
+	QueryContext(context.Context, ...interface{}) (Rows, error)
 	QueryRow(...interface{}) Row
+	QueryRowContext(context.Context, ...interface{}) Row
 }
 
 // Query provides a consise way of representing a SQL query consisting of the
@@ -66,16 +98,71 @@ func (q Query) String() string {
 	return fmt.Sprintf("[%s, %v]", q.Q, q.Args)
 }
 
-// DbRow represents a database row.
-// It implements the `dbc.Row` interface and wraps row operations using a
-// `sql.Row` type.
+// DbRow represents a single database row, as returned by `QueryRow`.
+// It implements the `dbc.Row` interface. Unlike `sql.Row`, it is built on
+// top of `sql.Rows` so that the column names remain available to
+// `StructScan`.
 type DbRow struct {
-	R *sql.Row
+	rows *sql.Rows
+	err  error
 }
 
-// Scan wraps the call to `sql.Row.Scan()`.
+// newDbRow builds a DbRow from the result of a `Query` call, mirroring how
+// `sql.QueryRow` is built on top of `sql.Query` internally.
+func newDbRow(rows *sql.Rows, err error) *DbRow {
+	return &DbRow{rows, err}
+}
+
+// Scan reads the first row of the result set into args, closing the result
+// set afterwards. It returns `sql.ErrNoRows` if the query matched no rows.
 func (r *DbRow) Scan(args ...interface{}) error {
-	return r.R.Scan(args)
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := r.rows.Scan(args...); err != nil {
+		return err
+	}
+	return r.rows.Close()
+}
+
+// DbRows represents the result set of a query returning multiple rows.
+// It implements the `dbc.Rows` interface and wraps row operations using a
+// `sql.Rows` type.
+type DbRows struct {
+	R *sql.Rows
+}
+
+// Close wraps the call to `sql.Rows.Close()`.
+func (rs *DbRows) Close() error {
+	return rs.R.Close()
+}
+
+// Columns wraps the call to `sql.Rows.Columns()`.
+func (rs *DbRows) Columns() ([]string, error) {
+	return rs.R.Columns()
+}
+
+// Err wraps the call to `sql.Rows.Err()`.
+func (rs *DbRows) Err() error {
+	return rs.R.Err()
+}
+
+// Next wraps the call to `sql.Rows.Next()`.
+func (rs *DbRows) Next() bool {
+	return rs.R.Next()
+}
+
+// Scan wraps the call to `sql.Rows.Scan()`.
+func (rs *DbRows) Scan(args ...interface{}) error {
+	return rs.R.Scan(args...)
 }
 
 // DbRowV represents the column values in a single row.
@@ -88,6 +175,18 @@ type DbRowV struct {
 // a `sql.Stmt` type.
 type DbStmt struct {
 	S *sql.Stmt
+
+	// query is the statement's original SQL text, kept only so hooks can
+	// report it (and so BatchInsert can recover the target table/columns
+	// for its bulk path); sql.Stmt does not expose it.
+	query string
+	hooks []Hook
+
+	// handle and driverName are the Handle that prepared this statement
+	// and its driver name, kept so BatchInsert can run its bulk path
+	// through the same connection/transaction.
+	handle     Handle
+	driverName string
 }
 
 // Close wraps the call to `sql.Stmt.Close()`.
@@ -97,59 +196,436 @@ func (st *DbStmt) Close() error {
 
 // Exec wraps the call to `sql.Stmt.Exec()`.
 func (st *DbStmt) Exec(args ...interface{}) (sql.Result, error) {
-	return st.S.Exec(args)
+	return st.ExecContext(context.Background(), args...)
+}
+
+// ExecContext wraps the call to `sql.Stmt.ExecContext()`, running any
+// registered hooks around it.
+func (st *DbStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	ctx, err := beforeQuery(ctx, st.hooks, OpExec, st.query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := st.S.ExecContext(ctx, args...)
+	afterQuery(ctx, st.hooks, OpExec, st.query, args, rowsAffected(res), err)
+	return res, err
 }
 
-// QueryRow wraps the call to `sql.Stmt.QueryRow` to return a database row.
+// Query wraps the call to `sql.Stmt.Query()` to return the matching rows.
+func (st *DbStmt) Query(args ...interface{}) (Rows, error) {
+	return st.QueryContext(context.Background(), args...)
+}
+
+// QueryContext wraps the call to `sql.Stmt.QueryContext()` to return the
+// matching rows, running any registered hooks around it.
+func (st *DbStmt) QueryContext(ctx context.Context, args ...interface{}) (Rows, error) {
+	ctx, err := beforeQuery(ctx, st.hooks, OpQuery, st.query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := st.S.QueryContext(ctx, args...)
+	afterQuery(ctx, st.hooks, OpQuery, st.query, args, 0, err)
+	if err != nil {
+		return nil, err
+	}
+	return &DbRows{r}, nil
+}
+
+// QueryRow runs `sql.Stmt.Query` and returns the first matching row.
 func (st *DbStmt) QueryRow(args ...interface{}) Row {
-	return &DbRow{st.S.QueryRow(args)}
+	return st.QueryRowContext(context.Background(), args...)
 }
 
-// BatchInsert inserts one or more rows.
+// QueryRowContext runs `sql.Stmt.QueryContext` and returns the first
+// matching row, running any registered hooks around it.
+func (st *DbStmt) QueryRowContext(ctx context.Context, args ...interface{}) Row {
+	ctx, err := beforeQuery(ctx, st.hooks, OpQueryRow, st.query, args)
+	if err != nil {
+		return newDbRow(nil, err)
+	}
+
+	r, err := st.S.QueryContext(ctx, args...)
+	afterQuery(ctx, st.hooks, OpQueryRow, st.query, args, 0, err)
+	return newDbRow(r, err)
+}
+
+// BatchInsert inserts rows, preferring the `BulkInsert` path (chunked
+// multi-row INSERTs, or COPY for the postgres driver) when st's query is a
+// single-row `INSERT INTO table (...) VALUES
+// (...)` statement. It is kept as a thin, backward-compatible wrapper
+// around `BulkInsert` for callers still using the `Prepare`-and-`Exec`
+// style; prefer calling `BulkInsert` directly in new code.
 func (st *DbStmt) BatchInsert(rows []DbRowV) error {
+	return st.BatchInsertContext(context.Background(), rows)
+}
+
+// BatchInsertContext inserts rows, aborting early if ctx is cancelled
+// before all rows have been written. See BatchInsert.
+func (st *DbStmt) BatchInsertContext(ctx context.Context, rows []DbRowV) error {
+	table, columns, ok := parseInsertColumns(st.query)
+	if !ok || st.handle == nil {
+		return st.legacyBatchInsertContext(ctx, rows)
+	}
+
+	_, err := st.handle.BulkInsert(ctx, table, columns, rows, BulkOpts{DriverName: st.driverName})
+	return err
+}
+
+// legacyBatchInsertContext inserts rows one `Exec` call per row. It backs
+// BatchInsertContext when st's query isn't a plain single-row INSERT that
+// BulkInsert's table/columns can be recovered from, e.g. an upsert with an
+// `ON CONFLICT`/`ON DUPLICATE KEY` clause.
+func (st *DbStmt) legacyBatchInsertContext(ctx context.Context, rows []DbRowV) error {
 	for _, r := range rows {
-		if _, err := st.Exec(r.V...); err != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := st.ExecContext(ctx, r.V...); err != nil {
 			return err
 		}
 	}
 
 	// Next call to `Exec` flushes the writes.
-	if _, err := st.Exec(); err != nil {
+	if _, err := st.ExecContext(ctx); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// DbHandle represents a database connection handle.
+// It implements the `dbc.Handle` interface and wraps connection operations
+// using a `sql.DB` type.
+type DbHandle struct {
+	DB   *sql.DB
+	bind Bind
+
+	// driverName is kept (beyond the Bind it selects) so BulkInsert can
+	// pick its postgres COPY fast path.
+	driverName string
+
+	hooks []Hook
+}
+
+// NewDbHandle wraps db, selecting the placeholder bind style named params
+// and `Rebind` use from driverName (see BindForDriver).
+func NewDbHandle(db *sql.DB, driverName string) *DbHandle {
+	return &DbHandle{DB: db, bind: BindForDriver(driverName), driverName: driverName}
+}
+
+// AddHook registers hook to observe every operation run through h, and any
+// *TxHandle/*DbStmt obtained from it.
+func (h *DbHandle) AddHook(hook Hook) {
+	h.hooks = append(h.hooks, hook)
+}
+
+// Exec wraps the call to `sql.DB.Exec()`.
+func (h *DbHandle) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return h.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext wraps the call to `sql.DB.ExecContext()`, running any
+// registered hooks around it.
+func (h *DbHandle) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, err := beforeQuery(ctx, h.hooks, OpExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := h.DB.ExecContext(ctx, query, args...)
+	afterQuery(ctx, h.hooks, OpExec, query, args, rowsAffected(res), err)
+	return res, err
+}
+
+// Prepare wraps the call to `sql.DB.Prepare()` and returns a prepared
+// statement.
+func (h *DbHandle) Prepare(query string) (Statement, error) {
+	return h.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext wraps the call to `sql.DB.PrepareContext()` and returns a
+// prepared statement, running any registered hooks around it.
+func (h *DbHandle) PrepareContext(ctx context.Context, query string) (Statement, error) {
+	ctx, err := beforeQuery(ctx, h.hooks, OpPrepare, query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := h.DB.PrepareContext(ctx, query)
+	afterQuery(ctx, h.hooks, OpPrepare, query, nil, 0, err)
+	if err != nil {
+		return nil, err
+	}
+	return &DbStmt{S: s, query: query, hooks: h.hooks, handle: h, driverName: h.driverName}, nil
+}
+
+// Query wraps the call to `sql.DB.Query()` to return the matching rows.
+func (h *DbHandle) Query(query string, args ...interface{}) (Rows, error) {
+	return h.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext wraps the call to `sql.DB.QueryContext()` to return the
+// matching rows, running any registered hooks around it.
+func (h *DbHandle) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	ctx, err := beforeQuery(ctx, h.hooks, OpQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := h.DB.QueryContext(ctx, query, args...)
+	afterQuery(ctx, h.hooks, OpQuery, query, args, 0, err)
+	if err != nil {
+		return nil, err
+	}
+	return &DbRows{r}, nil
+}
+
+// QueryRow runs `sql.DB.Query()` and returns the first matching row.
+func (h *DbHandle) QueryRow(query string, args ...interface{}) Row {
+	return h.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryRowContext runs `sql.DB.QueryContext()` and returns the first
+// matching row, running any registered hooks around it.
+func (h *DbHandle) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	ctx, err := beforeQuery(ctx, h.hooks, OpQueryRow, query, args)
+	if err != nil {
+		return newDbRow(nil, err)
+	}
+
+	r, err := h.DB.QueryContext(ctx, query, args...)
+	afterQuery(ctx, h.hooks, OpQueryRow, query, args, 0, err)
+	return newDbRow(r, err)
+}
+
+// BeginTx wraps the call to `sql.DB.BeginTx()` and returns a transaction
+// handle, running any registered hooks around it.
+func (h *DbHandle) BeginTx(ctx context.Context, opts *sql.TxOptions) (*TxHandle, error) {
+	ctx, err := beforeQuery(ctx, h.hooks, OpBegin, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := h.DB.BeginTx(ctx, opts)
+	afterQuery(ctx, h.hooks, OpBegin, "", nil, 0, err)
+	if err != nil {
+		return nil, err
+	}
+	return &TxHandle{T: t, bind: h.bind, driverName: h.driverName, hooks: h.hooks}, nil
+}
+
+// Rebind converts a query written with `?` placeholders into h's bind
+// style.
+func (h *DbHandle) Rebind(query string) string {
+	return Rebind(query, h.bind)
+}
+
+// NamedExec rewrites query's `:name` placeholders against arg (a
+// map[string]interface{} or a struct with `db:"..."` tags) and runs it
+// through Exec.
+func (h *DbHandle) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	nq, err := NewNamedQuery(query, arg, h.bind)
+	if err != nil {
+		return nil, err
+	}
+	return h.Exec(nq.Q, nq.Args...)
+}
+
+// NamedQueryRow rewrites query's `:name` placeholders against arg (a
+// map[string]interface{} or a struct with `db:"..."` tags) and runs it
+// through QueryRow.
+func (h *DbHandle) NamedQueryRow(query string, arg interface{}) Row {
+	nq, err := NewNamedQuery(query, arg, h.bind)
+	if err != nil {
+		return newDbRow(nil, err)
+	}
+	return h.QueryRow(nq.Q, nq.Args...)
+}
+
+// Get runs q against h and scans the single resulting row into dest, a
+// pointer to a struct. It returns `sql.ErrNoRows` unchanged when q matches
+// no row.
+func (h *DbHandle) Get(dest interface{}, q Query) error {
+	return getStruct(h, dest, q)
+}
+
+// Select runs q against h and scans every resulting row into dest, which
+// must be a pointer to a slice of structs or a slice of struct pointers.
+func (h *DbHandle) Select(dest interface{}, q Query) error {
+	return selectStructs(h, dest, q)
+}
+
+// BulkInsert inserts rows into table's columns using the fastest path
+// available for opts.DriverName, opening a transaction for the duration of
+// the call. It returns the total number of rows affected.
+func (h *DbHandle) BulkInsert(ctx context.Context, table string, columns []string, rows []DbRowV, opts BulkOpts) (int64, error) {
+	return bulkInsert(ctx, h, table, columns, rows, opts)
+}
+
 // TxHandle represents the transaction handle.
 // It implements the `dbc.Handle` interface and wraps transaction operations
 // using a `sql.Tx` type.
 type TxHandle struct {
-	T *sql.Tx
+	T    *sql.Tx
+	bind Bind
+
+	// driverName is kept (beyond the Bind it selects) so BulkInsert can
+	// pick its postgres COPY fast path.
+	driverName string
+
+	hooks []Hook
 }
 
 // Exec wraps the call to `sql.Tx.Exec()`.
-func (tx TxHandle) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return tx.T.Exec(query, args...)
+func (tx *TxHandle) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext wraps the call to `sql.Tx.ExecContext()`, running any
+// registered hooks around it.
+func (tx *TxHandle) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, err := beforeQuery(ctx, tx.hooks, OpExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.T.ExecContext(ctx, query, args...)
+	afterQuery(ctx, tx.hooks, OpExec, query, args, rowsAffected(res), err)
+	return res, err
 }
 
 // Prepare wraps the call to `sql.Tx.Prepare()` and returns a prepared statement.
 func (tx *TxHandle) Prepare(query string) (Statement, error) {
-	s, err := tx.T.Prepare(query)
-	return &DbStmt{s}, err
+	return tx.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext wraps the call to `sql.Tx.PrepareContext()` and returns a
+// prepared statement, running any registered hooks around it.
+func (tx *TxHandle) PrepareContext(ctx context.Context, query string) (Statement, error) {
+	ctx, err := beforeQuery(ctx, tx.hooks, OpPrepare, query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := tx.T.PrepareContext(ctx, query)
+	afterQuery(ctx, tx.hooks, OpPrepare, query, nil, 0, err)
+	if err != nil {
+		return nil, err
+	}
+	return &DbStmt{S: s, query: query, hooks: tx.hooks, handle: tx, driverName: tx.driverName}, nil
+}
+
+// Query wraps the call to `sql.Tx.Query()` to return the matching rows.
+func (tx *TxHandle) Query(query string, args ...interface{}) (Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext wraps the call to `sql.Tx.QueryContext()` to return the
+// matching rows, running any registered hooks around it.
+func (tx *TxHandle) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	ctx, err := beforeQuery(ctx, tx.hooks, OpQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := tx.T.QueryContext(ctx, query, args...)
+	afterQuery(ctx, tx.hooks, OpQuery, query, args, 0, err)
+	if err != nil {
+		return nil, err
+	}
+	return &DbRows{r}, nil
 }
 
-// QueryRow wraps the call to `sql.Tx.QueryRow()` and returns a database row.
+// QueryRow runs `sql.Tx.Query()` and returns the first matching row.
 func (tx *TxHandle) QueryRow(query string, args ...interface{}) Row {
-	return &DbRow{tx.T.QueryRow(query, args)}
+	return tx.QueryRowContext(context.Background(), query, args...)
 }
 
-// Commit wraps the call to `sql.Tx.Commit()`.
+// QueryRowContext runs `sql.Tx.QueryContext()` and returns the first
+// matching row, running any registered hooks around it.
+func (tx *TxHandle) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	ctx, err := beforeQuery(ctx, tx.hooks, OpQueryRow, query, args)
+	if err != nil {
+		return newDbRow(nil, err)
+	}
+
+	r, err := tx.T.QueryContext(ctx, query, args...)
+	afterQuery(ctx, tx.hooks, OpQueryRow, query, args, 0, err)
+	return newDbRow(r, err)
+}
+
+// Rebind converts a query written with `?` placeholders into tx's bind
+// style.
+func (tx *TxHandle) Rebind(query string) string {
+	return Rebind(query, tx.bind)
+}
+
+// NamedExec rewrites query's `:name` placeholders against arg (a
+// map[string]interface{} or a struct with `db:"..."` tags) and runs it
+// through Exec.
+func (tx *TxHandle) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	nq, err := NewNamedQuery(query, arg, tx.bind)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Exec(nq.Q, nq.Args...)
+}
+
+// NamedQueryRow rewrites query's `:name` placeholders against arg (a
+// map[string]interface{} or a struct with `db:"..."` tags) and runs it
+// through QueryRow.
+func (tx *TxHandle) NamedQueryRow(query string, arg interface{}) Row {
+	nq, err := NewNamedQuery(query, arg, tx.bind)
+	if err != nil {
+		return newDbRow(nil, err)
+	}
+	return tx.QueryRow(nq.Q, nq.Args...)
+}
+
+// Get runs q against tx and scans the single resulting row into dest, a
+// pointer to a struct. It returns `sql.ErrNoRows` unchanged when q matches
+// no row.
+func (tx *TxHandle) Get(dest interface{}, q Query) error {
+	return getStruct(tx, dest, q)
+}
+
+// Select runs q against tx and scans every resulting row into dest, which
+// must be a pointer to a slice of structs or a slice of struct pointers.
+func (tx *TxHandle) Select(dest interface{}, q Query) error {
+	return selectStructs(tx, dest, q)
+}
+
+// BulkInsert inserts rows into table's columns using the fastest path
+// available for opts.DriverName, reusing tx. It returns the total number
+// of rows affected.
+func (tx *TxHandle) BulkInsert(ctx context.Context, table string, columns []string, rows []DbRowV, opts BulkOpts) (int64, error) {
+	return bulkInsert(ctx, tx, table, columns, rows, opts)
+}
+
+// Commit wraps the call to `sql.Tx.Commit()`, running any registered hooks
+// around it.
 func (tx *TxHandle) Commit() error {
-	return tx.T.Commit()
+	ctx, err := beforeQuery(context.Background(), tx.hooks, OpCommit, "", nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.T.Commit()
+	afterQuery(ctx, tx.hooks, OpCommit, "", nil, 0, err)
+	return err
 }
 
-// Rollback wraps the call to `sql.Tx.Rollback()`.
+// Rollback wraps the call to `sql.Tx.Rollback()`, running any registered
+// hooks around it.
 func (tx *TxHandle) Rollback() error {
-	return tx.T.Rollback()
+	ctx, err := beforeQuery(context.Background(), tx.hooks, OpRollback, "", nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.T.Rollback()
+	afterQuery(ctx, tx.hooks, OpRollback, "", nil, 0, err)
+	return err
 }
@@ -0,0 +1,166 @@
+package dbc
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Op identifies the kind of operation a Hook observes.
+type Op int
+
+// Supported operations.
+const (
+	OpExec Op = iota
+	OpQueryRow
+	OpQuery
+	OpPrepare
+	OpBegin
+	OpCommit
+	OpRollback
+)
+
+// String returns op's name, e.g. "Exec".
+func (op Op) String() string {
+	switch op {
+	case OpExec:
+		return "Exec"
+	case OpQueryRow:
+		return "QueryRow"
+	case OpQuery:
+		return "Query"
+	case OpPrepare:
+		return "Prepare"
+	case OpBegin:
+		return "Begin"
+	case OpCommit:
+		return "Commit"
+	case OpRollback:
+		return "Rollback"
+	default:
+		return "Unknown"
+	}
+}
+
+// Hook observes Handle/Statement operations, e.g. for tracing, metrics, or
+// slow-query logging. BeforeQuery may return a derived context that is
+// threaded through to the underlying call and back into AfterQuery, and
+// may return an error to abort the operation before it reaches the driver.
+type Hook interface {
+	BeforeQuery(ctx context.Context, op Op, query string, args []interface{}) (context.Context, error)
+	AfterQuery(ctx context.Context, op Op, query string, args []interface{}, rowsAffected int64, err error)
+}
+
+// beforeQuery runs hooks' BeforeQuery in registration order, threading ctx
+// through each call and stopping at the first error. If a hook's
+// BeforeQuery fails, AfterQuery is run (with the abort error) for just the
+// earlier hooks whose BeforeQuery succeeded, in reverse order, so a hook
+// that started something in BeforeQuery (e.g. a span) always sees a
+// matching AfterQuery even when the operation never reaches the driver.
+func beforeQuery(ctx context.Context, hooks []Hook, op Op, query string, args []interface{}) (context.Context, error) {
+	for i, hk := range hooks {
+		var err error
+		ctx, err = hk.BeforeQuery(ctx, op, query, args)
+		if err != nil {
+			afterQuery(ctx, hooks[:i], op, query, args, 0, err)
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+// afterQuery runs hooks' AfterQuery in reverse registration order.
+func afterQuery(ctx context.Context, hooks []Hook, op Op, query string, args []interface{}, rowsAffected int64, err error) {
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterQuery(ctx, op, query, args, rowsAffected, err)
+	}
+}
+
+// rowsAffected returns res.RowsAffected(), or 0 if res is nil or the
+// driver doesn't support it.
+func rowsAffected(res sql.Result) int64 {
+	if res == nil {
+		return 0
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// SlowQueryHook logs a query via logger when it takes at least threshold to
+// complete. It ignores Begin/Commit/Rollback.
+func SlowQueryHook(threshold time.Duration, logger *log.Logger) Hook {
+	return &slowQueryHook{threshold: threshold, logger: logger}
+}
+
+type slowQueryHook struct {
+	threshold time.Duration
+	logger    *log.Logger
+}
+
+type slowQueryStartKey struct{}
+
+func (h *slowQueryHook) BeforeQuery(ctx context.Context, op Op, query string, args []interface{}) (context.Context, error) {
+	if op == OpBegin || op == OpCommit || op == OpRollback {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, slowQueryStartKey{}, time.Now()), nil
+}
+
+func (h *slowQueryHook) AfterQuery(ctx context.Context, op Op, query string, args []interface{}, rowsAffected int64, err error) {
+	start, ok := ctx.Value(slowQueryStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+
+	if d := time.Since(start); d >= h.threshold {
+		h.logger.Printf("dbc: slow query (%s, %s): %s %v", op, d, query, args)
+	}
+}
+
+// OTelHook returns a Hook that emits a span per operation via tracer, with
+// the SQL statement and operation name set as span attributes.
+func OTelHook(tracer trace.Tracer) Hook {
+	if tracer == nil {
+		tracer = otel.Tracer("dbc")
+	}
+	return &otelHook{tracer: tracer}
+}
+
+type otelHook struct {
+	tracer trace.Tracer
+}
+
+type otelSpanKey struct{}
+
+func (h *otelHook) BeforeQuery(ctx context.Context, op Op, query string, args []interface{}) (context.Context, error) {
+	ctx, span := h.tracer.Start(ctx, "dbc."+op.String(),
+		trace.WithAttributes(
+			attribute.String("db.operation", op.String()),
+			attribute.String("db.statement", query),
+		),
+	)
+	return context.WithValue(ctx, otelSpanKey{}, span), nil
+}
+
+func (h *otelHook) AfterQuery(ctx context.Context, op Op, query string, args []interface{}, rowsAffected int64, err error) {
+	span, ok := ctx.Value(otelSpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("db.rows_affected", rowsAffected))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
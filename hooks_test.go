@@ -0,0 +1,116 @@
+package dbc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingHook records the order BeforeQuery/AfterQuery are called in,
+// optionally failing BeforeQuery.
+type recordingHook struct {
+	name      string
+	failStart bool
+	calls     *[]string
+}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, op Op, query string, args []interface{}) (context.Context, error) {
+	*h.calls = append(*h.calls, "before:"+h.name)
+	if h.failStart {
+		return ctx, errors.New(h.name + ": boom")
+	}
+	return ctx, nil
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, op Op, query string, args []interface{}, rowsAffected int64, err error) {
+	*h.calls = append(*h.calls, "after:"+h.name)
+}
+
+func TestBeforeAfterQueryOrdering(t *testing.T) {
+	var calls []string
+	hooks := []Hook{
+		&recordingHook{name: "a", calls: &calls},
+		&recordingHook{name: "b", calls: &calls},
+	}
+
+	ctx, err := beforeQuery(context.Background(), hooks, OpExec, "SELECT 1", nil)
+	if err != nil {
+		t.Fatalf("beforeQuery() = %v, want nil", err)
+	}
+	afterQuery(ctx, hooks, OpExec, "SELECT 1", nil, 0, nil)
+
+	want := []string{"before:a", "before:b", "after:b", "after:a"}
+	if !equalStrings(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestBeforeQueryAbortRunsAfterQueryForStartedHooksOnly(t *testing.T) {
+	var calls []string
+	hooks := []Hook{
+		&recordingHook{name: "a", calls: &calls},
+		&recordingHook{name: "b", failStart: true, calls: &calls},
+		&recordingHook{name: "c", calls: &calls},
+	}
+
+	_, err := beforeQuery(context.Background(), hooks, OpExec, "SELECT 1", nil)
+	if err == nil {
+		t.Fatal("beforeQuery() = nil, want an error from hook b")
+	}
+
+	// Hook c's BeforeQuery never ran (b aborted first), so only a's
+	// AfterQuery should run to balance its BeforeQuery.
+	want := []string{"before:a", "before:b", "after:a"}
+	if !equalStrings(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSlowQueryHookLogsAboveThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	hook := &slowQueryHook{threshold: 5 * time.Millisecond, logger: log.New(&buf, "", 0)}
+
+	ctx, err := hook.BeforeQuery(context.Background(), OpExec, "SELECT 1", nil)
+	if err != nil {
+		t.Fatalf("BeforeQuery() = %v, want nil", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	hook.AfterQuery(ctx, OpExec, "SELECT 1", nil, 0, nil)
+
+	if !strings.Contains(buf.String(), "slow query") {
+		t.Errorf("log output = %q, want it to mention a slow query", buf.String())
+	}
+}
+
+func TestSlowQueryHookIgnoresFastQuery(t *testing.T) {
+	var buf bytes.Buffer
+	hook := &slowQueryHook{threshold: time.Hour, logger: log.New(&buf, "", 0)}
+
+	ctx, err := hook.BeforeQuery(context.Background(), OpExec, "SELECT 1", nil)
+	if err != nil {
+		t.Fatalf("BeforeQuery() = %v, want nil", err)
+	}
+
+	hook.AfterQuery(ctx, OpExec, "SELECT 1", nil, 0, nil)
+
+	if buf.Len() != 0 {
+		t.Errorf("log output = %q, want no output for a fast query", buf.String())
+	}
+}
@@ -0,0 +1,183 @@
+package dbc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Bind identifies the placeholder syntax a driver expects for positional
+// arguments.
+type Bind int
+
+// Supported bind types.
+const (
+	// Question is the `?` placeholder style used by MySQL and SQLite.
+	Question Bind = iota
+	// Dollar is the `$1`, `$2`, ... placeholder style used by Postgres.
+	Dollar
+)
+
+// BindForDriver returns the Bind a query against driverName should use,
+// defaulting to Question for unrecognised driver names.
+func BindForDriver(driverName string) Bind {
+	switch driverName {
+	case "postgres", "pgx", "pq":
+		return Dollar
+	default:
+		return Question
+	}
+}
+
+// NewNamedQuery builds a Query from q, a SQL statement containing
+// `:name`-style named parameters, and arg, a `map[string]interface{}` or a
+// struct (optionally with `db:"..."` tags). The named placeholders are
+// rewritten into bind's positional form and the matching values are
+// flattened into Args in the order the placeholders appear.
+func NewNamedQuery(q string, arg interface{}, bind Bind) (Query, error) {
+	names, rebound := bindNamedQuery(q, bind)
+
+	values, err := namedArgValues(arg, names)
+	if err != nil {
+		return Query{}, err
+	}
+
+	return Query{rebound, values}, nil
+}
+
+// bindNamedQuery rewrites every `:name` placeholder in q into bind's
+// positional form and returns the rewritten query along with the ordered
+// list of names it found. It copies quoted string/identifier spans
+// through untouched and treats a `::` (Postgres type cast) as literal
+// punctuation rather than the start of a placeholder, the same spans
+// sqlx's named-query parser skips.
+func bindNamedQuery(q string, bind Bind) ([]string, string) {
+	var names []string
+	var b strings.Builder
+	n := 0
+
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+
+		if c == '\'' || c == '"' {
+			j := quotedSpanEnd(q, i, c)
+			b.WriteString(q[i:j])
+			i = j - 1
+			continue
+		}
+
+		if c == ':' && i+1 < len(q) && q[i+1] == ':' {
+			b.WriteString("::")
+			i++
+			continue
+		}
+
+		if c != ':' || i+1 >= len(q) || !isNameStart(q[i+1]) {
+			b.WriteByte(c)
+			continue
+		}
+
+		j := i + 1
+		for j < len(q) && isNameByte(q[j]) {
+			j++
+		}
+		names = append(names, q[i+1:j])
+		n++
+
+		switch bind {
+		case Dollar:
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte('?')
+		}
+		i = j - 1
+	}
+
+	return names, b.String()
+}
+
+// quotedSpanEnd returns the index just past the quoted span in q that
+// starts at i (where q[i] == quote), treating a doubled quote (two single
+// quotes or two double quotes in a row) as an escaped quote character
+// rather than the end of the span. If the span is unterminated, it
+// returns len(q).
+func quotedSpanEnd(q string, i int, quote byte) int {
+	for j := i + 1; j < len(q); j++ {
+		if q[j] != quote {
+			continue
+		}
+		if j+1 < len(q) && q[j+1] == quote {
+			j++
+			continue
+		}
+		return j + 1
+	}
+	return len(q)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameByte(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// namedArgValues resolves names, in order, against arg which must be a
+// `map[string]interface{}` or a struct (optionally with `db:"..."` tags).
+func namedArgValues(arg interface{}, names []string) ([]interface{}, error) {
+	if m, ok := arg.(map[string]interface{}); ok {
+		values := make([]interface{}, len(names))
+		for i, name := range names {
+			v, ok := m[name]
+			if !ok {
+				return nil, fmt.Errorf("dbc: named parameter %q not found in arg map", name)
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dbc: named query arg must be a map[string]interface{} or a struct, got %T", arg)
+	}
+
+	fm := fieldMapFor(v.Type())
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		idx, ok := fm[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("dbc: named parameter %q has no matching field in %s", name, v.Type())
+		}
+		values[i] = v.FieldByIndex(idx).Interface()
+	}
+	return values, nil
+}
+
+// Rebind converts a query written with `?` placeholders into bind's
+// positional form, e.g. for Dollar it rewrites `?` into `$1`, `$2`, ...
+// It is a no-op for Question.
+func Rebind(query string, bind Bind) string {
+	if bind == Question {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] != '?' {
+			b.WriteByte(query[i])
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
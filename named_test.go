@@ -0,0 +1,97 @@
+package dbc
+
+import "testing"
+
+func TestBindNamedQuery(t *testing.T) {
+	cases := []struct {
+		name     string
+		query    string
+		bind     Bind
+		wantQ    string
+		wantArgs []string
+	}{
+		{
+			name:     "question style",
+			query:    "SELECT * FROM t WHERE id = :id AND name = :name",
+			bind:     Question,
+			wantQ:    "SELECT * FROM t WHERE id = ? AND name = ?",
+			wantArgs: []string{"id", "name"},
+		},
+		{
+			name:     "dollar style",
+			query:    "SELECT * FROM t WHERE id = :id AND name = :name",
+			bind:     Dollar,
+			wantQ:    "SELECT * FROM t WHERE id = $1 AND name = $2",
+			wantArgs: []string{"id", "name"},
+		},
+		{
+			name:     "postgres type cast is not a placeholder",
+			query:    "SELECT amount::numeric FROM t WHERE id = :id",
+			bind:     Dollar,
+			wantQ:    "SELECT amount::numeric FROM t WHERE id = $1",
+			wantArgs: []string{"id"},
+		},
+		{
+			name:     "colon inside single-quoted literal is not a placeholder",
+			query:    "SELECT * FROM t WHERE label = 'foo:bar' AND id = :id",
+			bind:     Question,
+			wantQ:    "SELECT * FROM t WHERE label = 'foo:bar' AND id = ?",
+			wantArgs: []string{"id"},
+		},
+		{
+			name:     "colon inside double-quoted identifier is not a placeholder",
+			query:    `SELECT * FROM t WHERE "weird:col" = :id`,
+			bind:     Question,
+			wantQ:    `SELECT * FROM t WHERE "weird:col" = ?`,
+			wantArgs: []string{"id"},
+		},
+		{
+			name:     "escaped quote inside literal",
+			query:    "SELECT * FROM t WHERE label = 'it''s:odd' AND id = :id",
+			bind:     Question,
+			wantQ:    "SELECT * FROM t WHERE label = 'it''s:odd' AND id = ?",
+			wantArgs: []string{"id"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, rebound := bindNamedQuery(tc.query, tc.bind)
+			if rebound != tc.wantQ {
+				t.Errorf("query = %q, want %q", rebound, tc.wantQ)
+			}
+			if len(names) != len(tc.wantArgs) {
+				t.Fatalf("names = %v, want %v", names, tc.wantArgs)
+			}
+			for i, n := range names {
+				if n != tc.wantArgs[i] {
+					t.Errorf("names[%d] = %q, want %q", i, n, tc.wantArgs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	if got := Rebind("SELECT * FROM t WHERE a = ? AND b = ?", Question); got != "SELECT * FROM t WHERE a = ? AND b = ?" {
+		t.Errorf("Question Rebind = %q", got)
+	}
+
+	want := "SELECT * FROM t WHERE a = $1 AND b = $2"
+	if got := Rebind("SELECT * FROM t WHERE a = ? AND b = ?", Dollar); got != want {
+		t.Errorf("Dollar Rebind = %q, want %q", got, want)
+	}
+}
+
+func TestNewNamedQueryWithMapArg(t *testing.T) {
+	q, err := NewNamedQuery("INSERT INTO t (a, b) VALUES (:a, :b)", map[string]interface{}{"a": 1, "b": "x"}, Dollar)
+	if err != nil {
+		t.Fatalf("NewNamedQuery returned error: %v", err)
+	}
+	if q.Q != "INSERT INTO t (a, b) VALUES ($1, $2)" {
+		t.Errorf("Q = %q", q.Q)
+	}
+	if len(q.Args) != 2 || q.Args[0] != 1 || q.Args[1] != "x" {
+		t.Errorf("Args = %v", q.Args)
+	}
+}
@@ -0,0 +1,207 @@
+package dbc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structTag is the struct tag used to map a field to a column name.
+const structTag = "db"
+
+// fieldMap maps a lower-cased column name to the index path of the struct
+// field it should be scanned into. The index path supports embedded
+// (anonymous) struct fields, e.g. []int{1, 0} means "field 0 of the struct
+// embedded at field 1".
+type fieldMap map[string][]int
+
+// fieldMapCache caches the column->field index map for each struct type so
+// repeated `StructScan` calls against the same type avoid re-walking the
+// type via reflection.
+var fieldMapCache sync.Map // map[reflect.Type]fieldMap
+
+func fieldMapFor(t reflect.Type) fieldMap {
+	if cached, ok := fieldMapCache.Load(t); ok {
+		return cached.(fieldMap)
+	}
+
+	fm := fieldMap{}
+	buildFieldMap(t, nil, fm)
+	fieldMapCache.Store(t, fm)
+	return fm
+}
+
+// buildFieldMap walks t's fields, recursing into anonymous (embedded)
+// structs, and records the column name each field maps to.
+func buildFieldMap(t reflect.Type, index []int, fm fieldMap) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			// Unexported, non-embedded field: cannot be set via reflection.
+			continue
+		}
+
+		fi := append(append([]int{}, index...), i)
+
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				buildFieldMap(ft, fi, fm)
+				continue
+			}
+		}
+
+		name := f.Tag.Get(structTag)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fm[strings.ToLower(name)] = fi
+	}
+}
+
+// fieldByIndex walks v, a struct value, along index, the same kind of index
+// path buildFieldMap records. Unlike `reflect.Value.FieldByIndex`, it
+// allocates any nil embedded pointer-to-struct field it passes through
+// instead of panicking, mirroring sqlx's reflectx.FieldByIndexes.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		v = reflect.Indirect(v).Field(i)
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+	}
+	return v
+}
+
+// structScanArgs builds the `Scan` argument list for dest, a pointer to a
+// struct, by matching cols against dest's `db:"..."` struct tags (falling
+// back to a case-insensitive field name match). It returns an error listing
+// any column with no matching field.
+func structScanArgs(dest interface{}, cols []string) ([]interface{}, error) {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return nil, fmt.Errorf("dbc: StructScan destination must be a non-nil pointer to a struct")
+	}
+
+	sv := dv.Elem()
+	if sv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dbc: StructScan destination must be a pointer to a struct, got %s", sv.Kind())
+	}
+
+	fm := fieldMapFor(sv.Type())
+
+	var unmapped []string
+	vals := make([]interface{}, len(cols))
+	for i, c := range cols {
+		idx, ok := fm[strings.ToLower(c)]
+		if !ok {
+			unmapped = append(unmapped, c)
+			vals[i] = new(interface{})
+			continue
+		}
+		vals[i] = fieldByIndex(sv, idx).Addr().Interface()
+	}
+
+	if len(unmapped) > 0 {
+		return nil, fmt.Errorf("dbc: no struct field found for columns %v", unmapped)
+	}
+
+	return vals, nil
+}
+
+// StructScan scans the row into dest, a pointer to a struct, by matching
+// column names against `db:"..."` struct tags (falling back to a
+// case-insensitive field name match). Embedded structs are walked
+// recursively. It returns `sql.ErrNoRows` unchanged when the query matched
+// no row.
+func (r *DbRow) StructScan(dest interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	cols, err := r.rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	vals, err := structScanArgs(dest, cols)
+	if err != nil {
+		return err
+	}
+	return r.Scan(vals...)
+}
+
+// StructScan scans the current row into dest, a pointer to a struct, by
+// matching column names against `db:"..."` struct tags (falling back to a
+// case-insensitive field name match). Embedded structs are walked
+// recursively.
+func (rs *DbRows) StructScan(dest interface{}) error {
+	cols, err := rs.Columns()
+	if err != nil {
+		return err
+	}
+
+	vals, err := structScanArgs(dest, cols)
+	if err != nil {
+		return err
+	}
+	return rs.Scan(vals...)
+}
+
+// getStruct runs q against h and scans the single resulting row into dest,
+// a pointer to a struct. It returns `sql.ErrNoRows` unchanged when q
+// matches no row. It backs the `Handle.Get` method.
+func getStruct(h Handle, dest interface{}, q Query) error {
+	return h.QueryRow(q.Q, q.Args...).(*DbRow).StructScan(dest)
+}
+
+// selectStructs runs q against h and scans every resulting row into dest,
+// which must be a pointer to a slice of structs or a slice of struct
+// pointers. It backs the `Handle.Select` method.
+func selectStructs(h Handle, dest interface{}, q Query) error {
+	rows, err := h.Query(q.Q, q.Args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	drs, ok := rows.(*DbRows)
+	if !ok {
+		return fmt.Errorf("dbc: Select requires *DbRows, got %T", rows)
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dbc: Select destination must be a pointer to a slice")
+	}
+
+	sliceV := dv.Elem()
+	elemT := sliceV.Type().Elem()
+	elemIsPtr := elemT.Kind() == reflect.Ptr
+	structT := elemT
+	if elemIsPtr {
+		structT = elemT.Elem()
+	}
+
+	for drs.Next() {
+		ev := reflect.New(structT)
+		if err := drs.StructScan(ev.Interface()); err != nil {
+			return err
+		}
+
+		if elemIsPtr {
+			sliceV.Set(reflect.Append(sliceV, ev))
+		} else {
+			sliceV.Set(reflect.Append(sliceV, ev.Elem()))
+		}
+	}
+
+	return drs.Err()
+}
@@ -0,0 +1,48 @@
+package dbc
+
+import (
+	"reflect"
+	"testing"
+)
+
+type ScanInner struct {
+	Age int `db:"age"`
+}
+
+type scanOuterPtr struct {
+	*ScanInner
+	Name string `db:"name"`
+}
+
+func TestBuildFieldMapEmbeddedPointer(t *testing.T) {
+	fm := fieldMapFor(reflect.TypeOf(scanOuterPtr{}))
+
+	if _, ok := fm["age"]; !ok {
+		t.Fatalf("expected fieldMap to contain %q, got %v", "age", fm)
+	}
+	if _, ok := fm["name"]; !ok {
+		t.Fatalf("expected fieldMap to contain %q, got %v", "name", fm)
+	}
+}
+
+func TestStructScanArgsAllocatesNilEmbeddedPointer(t *testing.T) {
+	dest := &scanOuterPtr{}
+
+	vals, err := structScanArgs(dest, []string{"name", "age"})
+	if err != nil {
+		t.Fatalf("structScanArgs returned error: %v", err)
+	}
+	if len(vals) != 2 {
+		t.Fatalf("expected 2 scan args, got %d", len(vals))
+	}
+	if dest.ScanInner == nil {
+		t.Fatalf("expected structScanArgs to allocate the nil embedded pointer")
+	}
+
+	*(vals[0].(*string)) = "ada"
+	*(vals[1].(*int)) = 42
+
+	if dest.Name != "ada" || dest.Age != 42 {
+		t.Fatalf("unexpected dest after scan: %+v", dest)
+	}
+}
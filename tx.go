@@ -0,0 +1,203 @@
+package dbc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how RunInTx retries a transaction whose commit or
+// body failed with a retryable error (e.g. a serialization failure or
+// deadlock).
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times the transaction is run,
+	// including the first attempt. A value <= 1 disables retries.
+	MaxAttempts int
+	// Backoff returns how long to sleep before attempt (1-indexed, the
+	// attempt about to be retried) is started. If nil, no delay is added.
+	Backoff func(attempt int) time.Duration
+	// IsRetryable classifies whether an error is safe to retry. If nil,
+	// the package-level IsRetryable is used, which only recognizes
+	// MySQL's and Postgres's own error texts; callers on a different
+	// driver, or wrapping errors in their own types, should set this to
+	// adapt.
+	IsRetryable func(err error) bool
+}
+
+// noRetry never retries; it is the default RetryPolicy used by RunInTx.
+var noRetry = RetryPolicy{MaxAttempts: 1}
+
+// IsRetryable reports whether err indicates a transaction that failed for a
+// transient reason and is safe to retry, e.g. a MySQL deadlock (error 1213)
+// or a Postgres serialization failure (SQLSTATE 40001/40P01).
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "Error 1213"): // MySQL: deadlock found when trying to get lock
+		return true
+	case strings.Contains(msg, "40001"): // Postgres: serialization_failure
+		return true
+	case strings.Contains(msg, "40P01"): // Postgres: deadlock_detected
+		return true
+	default:
+		return false
+	}
+}
+
+// RunInTx runs fn inside a transaction opened with opts: it begins the
+// transaction, invokes fn, commits on a nil return and rolls back
+// otherwise. A panic inside fn is converted into a rollback followed by a
+// re-panic. If retry is non-nil and the body or commit fails with an error
+// retry.IsRetryable (or, if unset, IsRetryable) accepts, the transaction is
+// rolled back and retried, running at most retry.MaxAttempts times in all.
+func (h *DbHandle) RunInTx(ctx context.Context, opts *sql.TxOptions, retry *RetryPolicy, fn func(tx *TxHandle) error) error {
+	policy := noRetry
+	if retry != nil {
+		policy = *retry
+	}
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	isRetryable := IsRetryable
+	if policy.IsRetryable != nil {
+		isRetryable = policy.IsRetryable
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		if attempt > 1 && policy.Backoff != nil {
+			if err := sleepBackoff(ctx, policy.Backoff(attempt)); err != nil {
+				return err
+			}
+		}
+
+		err := runTxOnce(ctx, h, opts, fn)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if attempt == policy.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+	}
+
+	return lastErr
+}
+
+// sleepBackoff blocks for d, returning ctx.Err() early if ctx is done
+// first, so a cancelled context interrupts a pending retry backoff instead
+// of waiting it out.
+func sleepBackoff(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// runTxOnce runs a single attempt of fn inside a transaction.
+func runTxOnce(ctx context.Context, h *DbHandle, opts *sql.TxOptions, fn func(tx *TxHandle) error) (err error) {
+	tx, err := h.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// savepointNameRe matches a safe, unquoted SQL identifier. Savepoint names
+// are spliced directly into SQL text (savepoints can't be bound as query
+// parameters), so names are restricted to this pattern rather than quoted.
+var savepointNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateSavepointName returns an error if name is not a safe, unquoted
+// SQL identifier, e.g. one derived from unsanitized caller input.
+func validateSavepointName(name string) error {
+	if !savepointNameRe.MatchString(name) {
+		return fmt.Errorf("dbc: invalid savepoint name %q", name)
+	}
+	return nil
+}
+
+// Savepoint creates a savepoint named name within tx.
+func (tx *TxHandle) Savepoint(name string) error {
+	if err := validateSavepointName(name); err != nil {
+		return err
+	}
+	_, err := tx.Exec(fmt.Sprintf("SAVEPOINT %s", name))
+	return err
+}
+
+// ReleaseSavepoint releases the savepoint named name, keeping the work done
+// since it was created.
+func (tx *TxHandle) ReleaseSavepoint(name string) error {
+	if err := validateSavepointName(name); err != nil {
+		return err
+	}
+	_, err := tx.Exec(fmt.Sprintf("RELEASE SAVEPOINT %s", name))
+	return err
+}
+
+// RollbackTo rolls tx back to the savepoint named name, undoing any work
+// done since it was created without aborting the outer transaction.
+func (tx *TxHandle) RollbackTo(name string) error {
+	if err := validateSavepointName(name); err != nil {
+		return err
+	}
+	_, err := tx.Exec(fmt.Sprintf("ROLLBACK TO SAVEPOINT %s", name))
+	return err
+}
+
+// savepointSeq generates unique savepoint names for nested RunInTx calls.
+var savepointSeq uint64
+
+// RunInTx emulates a nested transaction by running fn inside a savepoint:
+// it creates a savepoint, invokes fn, releases the savepoint on a nil
+// return and rolls back to it otherwise. A panic inside fn rolls back to
+// the savepoint and re-panics.
+func (tx *TxHandle) RunInTx(fn func(tx *TxHandle) error) (err error) {
+	name := fmt.Sprintf("dbc_sp_%d", atomic.AddUint64(&savepointSeq, 1))
+
+	if err := tx.Savepoint(name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.RollbackTo(name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.RollbackTo(name); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	return tx.ReleaseSavepoint(name)
+}
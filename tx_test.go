@@ -0,0 +1,87 @@
+package dbc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"mysql deadlock", errors.New("Error 1213: Deadlock found when trying to get lock"), true},
+		{"postgres serialization failure", errors.New("pq: could not serialize access due to concurrent update (SQLSTATE 40001)"), true},
+		{"postgres deadlock", errors.New("pq: deadlock detected (SQLSTATE 40P01)"), true},
+		{"unrelated error", errors.New("pq: relation \"t\" does not exist"), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsRetryable(tc.err); got != tc.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyCustomClassifierOverridesDefault(t *testing.T) {
+	errLocal := errors.New("locally wrapped conflict")
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		IsRetryable: func(err error) bool { return errors.Is(err, errLocal) },
+	}
+
+	// Mirrors how RunInTx picks its classifier: policy.IsRetryable when
+	// set, falling back to the package-level IsRetryable otherwise.
+	isRetryable := IsRetryable
+	if policy.IsRetryable != nil {
+		isRetryable = policy.IsRetryable
+	}
+
+	if !isRetryable(errLocal) {
+		t.Errorf("expected the custom IsRetryable to accept errLocal")
+	}
+	if IsRetryable(errLocal) {
+		t.Errorf("expected the package-level IsRetryable to reject errLocal")
+	}
+}
+
+func TestSleepBackoffHonorsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := sleepBackoff(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("sleepBackoff() = %v, want context.Canceled", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("sleepBackoff blocked for %s on a cancelled context, want immediate return", d)
+	}
+}
+
+func TestSleepBackoffWaitsOutUncancelledContext(t *testing.T) {
+	if err := sleepBackoff(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("sleepBackoff() = %v, want nil", err)
+	}
+}
+
+func TestValidateSavepointName(t *testing.T) {
+	valid := []string{"sp1", "_sp", "dbc_sp_1", "A"}
+	for _, name := range valid {
+		if err := validateSavepointName(name); err != nil {
+			t.Errorf("validateSavepointName(%q) = %v, want nil", name, err)
+		}
+	}
+
+	invalid := []string{"", "1sp", "sp;DROP TABLE t", "sp name", "sp-1", "sp'"}
+	for _, name := range invalid {
+		if err := validateSavepointName(name); err == nil {
+			t.Errorf("validateSavepointName(%q) = nil, want error", name)
+		}
+	}
+}